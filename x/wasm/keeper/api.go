@@ -0,0 +1,51 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	wasmvm "github.com/CosmWasm/wasmvm"
+)
+
+// cosmwasmAPI builds the wasmvm.GoAPI callbacks used by the VM to translate and validate
+// addresses, billing each call in SDK gas through the keeper's gas register. The costs
+// themselves live on WasmGasRegisterConfig (HumanizeCost/CanonicalizeCost/ValidateCost) rather
+// than as consts here, so a chain overriding WithGasRegister can reprice them independently of
+// InstanceCost/CompileCost/event costs. ctx is threaded through only so a profiled call (see
+// gasProfileFromContext) can attribute these costs to GasProfile.HostFnCost.
+func (k Keeper) cosmwasmAPI(ctx sdk.Context) wasmvm.GoAPI {
+	return wasmvm.GoAPI{
+		HumanAddress:     func(canon []byte) (string, uint64, error) { return k.humanAddress(ctx, canon) },
+		CanonicalAddress: func(human string) ([]byte, uint64, error) { return k.canonicalAddress(ctx, human) },
+		ValidateAddress:  func(human string) (uint64, error) { return k.validateAddress(ctx, human) },
+	}
+}
+
+func (k Keeper) humanAddress(ctx sdk.Context, canon []byte) (string, uint64, error) {
+	addr := sdk.AccAddress(canon)
+	k.recordHostFnCost(ctx, k.gasRegister.HumanizeCost())
+	return addr.String(), k.gasRegister.ToWasmVMGas(k.gasRegister.HumanizeCost()), nil
+}
+
+func (k Keeper) canonicalAddress(ctx sdk.Context, human string) ([]byte, uint64, error) {
+	k.recordHostFnCost(ctx, k.gasRegister.CanonicalizeCost())
+	gasCost := k.gasRegister.ToWasmVMGas(k.gasRegister.CanonicalizeCost())
+	addr, err := sdk.AccAddressFromBech32(human)
+	if err != nil {
+		return nil, gasCost, err
+	}
+	return addr, gasCost, nil
+}
+
+func (k Keeper) validateAddress(ctx sdk.Context, human string) (uint64, error) {
+	k.recordHostFnCost(ctx, k.gasRegister.ValidateCost())
+	_, err := sdk.AccAddressFromBech32(human)
+	return k.gasRegister.ToWasmVMGas(k.gasRegister.ValidateCost()), err
+}
+
+// recordHostFnCost appends a GoAPI host callback's SDK gas cost onto the in-flight GasProfile, if
+// one is attached to ctx. It is a no-op outside of a profiled call (types.EnableGasVerification).
+func (k Keeper) recordHostFnCost(ctx sdk.Context, cost sdk.Gas) {
+	if profile := gasProfileFromContext(ctx); profile != nil {
+		profile.HostFnCost += uint64(cost)
+	}
+}
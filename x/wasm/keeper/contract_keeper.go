@@ -0,0 +1,179 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+
+	"github.com/CosmWasm/wasmd/x/wasm/types"
+)
+
+// InstantiateWithProfile behaves exactly like instantiate, but additionally returns a structured
+// types.GasProfile breakdown of the call, following the same types.EnableGasVerification-gated
+// pattern as QuerySmartWithProfile.
+func (k Keeper) InstantiateWithProfile(ctx sdk.Context, contractAddr sdk.AccAddress, creator sdk.AccAddress, initMsg []byte, pinned bool) (*wasmvmtypes.Response, types.GasProfile, error) {
+	if !types.EnableGasVerification {
+		res, err := k.instantiate(ctx, contractAddr, creator, initMsg, pinned, nil)
+		return res, types.GasProfile{}, err
+	}
+	profile := &types.GasProfile{}
+	res, err := k.instantiate(ctx, contractAddr, creator, initMsg, pinned, profile)
+	return res, *profile, err
+}
+
+// instantiate creates a new contract instance by invoking the contract's instantiate entry point,
+// charging the instance-load cost through the keeper's gas register before handing off to wasmvm.
+// The call is bounded by Params.MaxContractGas, the same circuit breaker QuerySmart enforces.
+// profile is nil unless the caller wants the structured breakdown (see InstantiateWithProfile).
+func (k Keeper) instantiate(ctx sdk.Context, contractAddr sdk.AccAddress, creator sdk.AccAddress, initMsg []byte, pinned bool, profile *types.GasProfile) (*wasmvmtypes.Response, error) {
+	parentMeter := ctx.GasMeter()
+	ctx = k.capContractGasMeter(ctx)
+	if profile != nil {
+		ctx = ctx.WithValue(contextKeyGasProfile{}, profile)
+	}
+
+	instanceCost := k.gasRegister.NewContractInstanceCosts(pinned, len(initMsg))
+	ctx.GasMeter().ConsumeGas(instanceCost, "loading CosmWasm module: instantiate")
+
+	env := types.NewEnv(ctx, contractAddr)
+	info := wasmvmtypes.MessageInfo{Sender: creator.String()}
+	queryHandler := NewQueryHandler(ctx, contractAddr, k.queryPlugins, k)
+
+	gasLeft := k.gasRegister.ToWasmVMGas(ctx.GasMeter().GasRemaining())
+	res, gasUsed, err := k.wasmVM.Instantiate(env, info, contractAddr, initMsg, k.cosmwasmAPI(ctx), queryHandler, ctx.GasMeter(), gasLeft)
+	vmCost := k.gasRegister.FromWasmVMGas(gasUsed)
+	ctx.GasMeter().ConsumeGas(vmCost, "instantiate contract")
+	if err == nil {
+		ctx.GasMeter().ConsumeGas(k.gasRegister.EventCosts(res.Attributes, res.Events), "contract event attributes")
+	}
+
+	if ctx.GasMeter() != parentMeter {
+		parentMeter.ConsumeGas(ctx.GasMeter().GasConsumed(), "contract call bounded by MaxContractGas")
+	}
+	if profile != nil {
+		profile.InstanceCost = uint64(instanceCost)
+		profile.VMCost = uint64(vmCost)
+	}
+	if err != nil {
+		return nil, sdkerrors.Wrap(types.ErrInvalid, err.Error())
+	}
+	return res, nil
+}
+
+// ExecuteWithProfile behaves exactly like execute, but additionally returns a structured
+// types.GasProfile breakdown of the call, following the same types.EnableGasVerification-gated
+// pattern as QuerySmartWithProfile.
+func (k Keeper) ExecuteWithProfile(ctx sdk.Context, contractAddr sdk.AccAddress, caller sdk.AccAddress, msg []byte, pinned bool) (*wasmvmtypes.Response, types.GasProfile, error) {
+	if !types.EnableGasVerification {
+		res, err := k.execute(ctx, contractAddr, caller, msg, pinned, nil)
+		return res, types.GasProfile{}, err
+	}
+	profile := &types.GasProfile{}
+	res, err := k.execute(ctx, contractAddr, caller, msg, pinned, profile)
+	return res, *profile, err
+}
+
+// execute calls a contract's execute entry point, charging the instance-load cost through the
+// keeper's gas register before handing off to wasmvm. The call is bounded by Params.MaxContractGas.
+// profile is nil unless the caller wants the structured breakdown (see ExecuteWithProfile).
+func (k Keeper) execute(ctx sdk.Context, contractAddr sdk.AccAddress, caller sdk.AccAddress, msg []byte, pinned bool, profile *types.GasProfile) (*wasmvmtypes.Response, error) {
+	parentMeter := ctx.GasMeter()
+	ctx = k.capContractGasMeter(ctx)
+	if profile != nil {
+		ctx = ctx.WithValue(contextKeyGasProfile{}, profile)
+	}
+
+	instanceCost := k.gasRegister.NewContractInstanceCosts(pinned, len(msg))
+	ctx.GasMeter().ConsumeGas(instanceCost, "loading CosmWasm module: execute")
+
+	env := types.NewEnv(ctx, contractAddr)
+	info := wasmvmtypes.MessageInfo{Sender: caller.String()}
+	queryHandler := NewQueryHandler(ctx, contractAddr, k.queryPlugins, k)
+
+	gasLeft := k.gasRegister.ToWasmVMGas(ctx.GasMeter().GasRemaining())
+	res, gasUsed, err := k.wasmVM.Execute(env, info, contractAddr, msg, k.cosmwasmAPI(ctx), queryHandler, ctx.GasMeter(), gasLeft)
+	vmCost := k.gasRegister.FromWasmVMGas(gasUsed)
+	ctx.GasMeter().ConsumeGas(vmCost, "execute contract")
+	if err == nil {
+		ctx.GasMeter().ConsumeGas(k.gasRegister.EventCosts(res.Attributes, res.Events), "contract event attributes")
+	}
+
+	if ctx.GasMeter() != parentMeter {
+		parentMeter.ConsumeGas(ctx.GasMeter().GasConsumed(), "contract call bounded by MaxContractGas")
+	}
+	if profile != nil {
+		profile.InstanceCost = uint64(instanceCost)
+		profile.VMCost = uint64(vmCost)
+	}
+	if err != nil {
+		return nil, sdkerrors.Wrap(types.ErrInvalid, err.Error())
+	}
+	return res, nil
+}
+
+// migrate calls a contract's migrate entry point, charging the (always unpinned) instance-load
+// cost through the keeper's gas register before handing off to wasmvm. The call is bounded by
+// Params.MaxContractGas.
+func (k Keeper) migrate(ctx sdk.Context, contractAddr sdk.AccAddress, msg []byte) (*wasmvmtypes.Response, error) {
+	parentMeter := ctx.GasMeter()
+	ctx = k.capContractGasMeter(ctx)
+
+	ctx.GasMeter().ConsumeGas(k.gasRegister.NewContractInstanceCosts(false, len(msg)), "loading CosmWasm module: migrate")
+
+	env := types.NewEnv(ctx, contractAddr)
+	queryHandler := NewQueryHandler(ctx, contractAddr, k.queryPlugins, k)
+
+	gasLeft := k.gasRegister.ToWasmVMGas(ctx.GasMeter().GasRemaining())
+	res, gasUsed, err := k.wasmVM.Migrate(env, contractAddr, msg, k.cosmwasmAPI(ctx), queryHandler, ctx.GasMeter(), gasLeft)
+	ctx.GasMeter().ConsumeGas(k.gasRegister.FromWasmVMGas(gasUsed), "migrate contract")
+
+	if ctx.GasMeter() != parentMeter {
+		parentMeter.ConsumeGas(ctx.GasMeter().GasConsumed(), "contract call bounded by MaxContractGas")
+	}
+	if err != nil {
+		return nil, sdkerrors.Wrap(types.ErrInvalid, err.Error())
+	}
+	return res, nil
+}
+
+// ReplyWithProfile behaves exactly like reply, but additionally returns a structured
+// types.GasProfile breakdown of the call, following the same types.EnableGasVerification-gated
+// pattern as QuerySmartWithProfile.
+func (k Keeper) ReplyWithProfile(ctx sdk.Context, contractAddr sdk.AccAddress, replyMsg wasmvmtypes.Reply, pinned bool) (*wasmvmtypes.Response, types.GasProfile, error) {
+	if !types.EnableGasVerification {
+		res, err := k.reply(ctx, contractAddr, replyMsg, pinned, nil)
+		return res, types.GasProfile{}, err
+	}
+	profile := &types.GasProfile{}
+	res, err := k.reply(ctx, contractAddr, replyMsg, pinned, profile)
+	return res, *profile, err
+}
+
+// reply calls a contract's reply entry point after a submessage completes, charging the
+// reply-specific cost computed by the gas register: the instance-load cost plus the cost of the
+// submessage's own events and data (or error text). profile is nil unless the caller wants the
+// structured breakdown (see ReplyWithProfile).
+func (k Keeper) reply(ctx sdk.Context, contractAddr sdk.AccAddress, reply wasmvmtypes.Reply, pinned bool, profile *types.GasProfile) (*wasmvmtypes.Response, error) {
+	if profile != nil {
+		ctx = ctx.WithValue(contextKeyGasProfile{}, profile)
+	}
+	replyCost := k.gasRegister.ReplyCosts(pinned, reply)
+	ctx.GasMeter().ConsumeGas(replyCost, "loading CosmWasm module: reply")
+
+	env := types.NewEnv(ctx, contractAddr)
+	queryHandler := NewQueryHandler(ctx, contractAddr, k.queryPlugins, k)
+
+	gasLeft := k.gasRegister.ToWasmVMGas(ctx.GasMeter().GasRemaining())
+	res, gasUsed, err := k.wasmVM.Reply(env, contractAddr, reply, k.cosmwasmAPI(ctx), queryHandler, ctx.GasMeter(), gasLeft)
+	vmCost := k.gasRegister.FromWasmVMGas(gasUsed)
+	ctx.GasMeter().ConsumeGas(vmCost, "reply to contract")
+	if profile != nil {
+		profile.ReplyCost = uint64(replyCost)
+		profile.VMCost = uint64(vmCost)
+	}
+	if err != nil {
+		return nil, sdkerrors.Wrap(types.ErrInvalid, err.Error())
+	}
+	return res, nil
+}
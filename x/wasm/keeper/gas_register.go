@@ -0,0 +1,224 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+)
+
+// Default gas costs. These are used when no GasRegister override is wired through
+// NewKeeper via WithGasRegister, and they back DefaultGasRegisterConfig.
+const (
+	// DefaultGasMultiplier is how many CosmWasm (Wasmer) gas points equal 1 Cosmos SDK gas point.
+	// Please note that all gas prices returned to the wasmvm engine are pre-multiplied, so that
+	// contracts always see sdk gas, never wasmvm gas.
+	DefaultGasMultiplier uint64 = 140_000_000
+	// DefaultInstanceCost is how much SDK gas we charge each time we load a WASM instance.
+	// Creating a new instance is costly, and this helps put a fair price on the protection offered
+	// by the Wasmer sandbox.
+	DefaultInstanceCost uint64 = 60_000
+	// DefaultCompileCost is how much SDK gas is charged *per byte* for compiling a new wasm contract.
+	DefaultCompileCost uint64 = 2
+	// DefaultContractMessageDataCost is how much SDK gas is charged *per byte* of the
+	// instantiate/execute/migrate/query message passed into a contract call.
+	DefaultContractMessageDataCost uint64 = 1
+	// DefaultEventAttributeDataCost is how much SDK gas is charged *per byte* of event attribute
+	// data (beyond the free tier below).
+	DefaultEventAttributeDataCost uint64 = 1
+	// DefaultEventAttributeDataFreeTier is the number of bytes of event attribute data per message
+	// that are not charged, to keep small, well-behaved contracts cheap.
+	DefaultEventAttributeDataFreeTier uint64 = 100
+	// DefaultPerAttributeCost is how much SDK gas we charge per event attribute count.
+	DefaultPerAttributeCost uint64 = 10
+	// DefaultPerCustomEventCost is how much SDK gas we charge per custom event emitted.
+	DefaultPerCustomEventCost uint64 = 20
+	// DefaultHumanizeCost is how much SDK gas the HumanAddress GoAPI callback costs.
+	DefaultHumanizeCost uint64 = 5
+	// DefaultCanonicalizeCost is how much SDK gas the CanonicalAddress GoAPI callback costs.
+	DefaultCanonicalizeCost uint64 = 4
+	// DefaultValidateCost is how much SDK gas the ValidateAddress GoAPI callback costs.
+	DefaultValidateCost uint64 = 3
+)
+
+// GasRegister abstracts all gas-accounting decisions the keeper makes when it
+// interacts with the wasmvm engine, so that chains can tune or replace the cost
+// model without forking the keeper itself.
+type GasRegister interface {
+	// NewContractInstanceCosts costs when instantiating a new contract instance in wasmvm.
+	NewContractInstanceCosts(pinned bool, msgLen int) sdk.Gas
+	// CompileCosts costs to persist and "compile" a new wasm contract blob.
+	CompileCosts(byteLen int) sdk.Gas
+	// EventCosts costs to persist the given events and attributes emitted by a contract call.
+	EventCosts(attrs []wasmvmtypes.EventAttribute, events wasmvmtypes.Events) sdk.Gas
+	// ReplyCosts costs to handle the reply to a submessage in wasmd.
+	ReplyCosts(pinned bool, reply wasmvmtypes.Reply) sdk.Gas
+	// HumanizeCost costs the HumanAddress GoAPI callback, in SDK gas.
+	HumanizeCost() sdk.Gas
+	// CanonicalizeCost costs the CanonicalAddress GoAPI callback, in SDK gas.
+	CanonicalizeCost() sdk.Gas
+	// ValidateCost costs the ValidateAddress GoAPI callback, in SDK gas.
+	ValidateCost() sdk.Gas
+	// ToWasmVMGas converts from SDK gas to wasmvm gas units.
+	ToWasmVMGas(source sdk.Gas) uint64
+	// FromWasmVMGas converts from wasmvm gas units back to SDK gas.
+	FromWasmVMGas(source uint64) sdk.Gas
+}
+
+// WasmGasRegisterConfig holds the tunable constants consumed by WasmGasRegister. A chain can
+// start from DefaultGasRegisterConfig and override individual fields to change the cost model.
+type WasmGasRegisterConfig struct {
+	// InstanceCost is the flat cost charged when a new (non-pinned) contract instance is loaded.
+	InstanceCost sdk.Gas
+	// CompileCost is charged per byte when a new wasm contract blob is stored.
+	CompileCost sdk.Gas
+	// ContractMessageDataCost is charged per byte of the instantiate/execute/migrate/query
+	// message passed into a contract call. It is kept separate from EventAttributeDataCost so a
+	// chain can reprice one without the other.
+	ContractMessageDataCost sdk.Gas
+	// GasMultiplier is how many wasmvm gas units equal 1 SDK gas unit.
+	GasMultiplier sdk.Gas
+	// EventPerAttributeCost is charged per event attribute, regardless of size.
+	EventPerAttributeCost sdk.Gas
+	// EventAttributeDataCost is charged per byte of attribute data beyond EventAttributeDataFreeTier.
+	EventAttributeDataCost sdk.Gas
+	// EventAttributeDataFreeTier is the number of attribute data bytes per call that are free.
+	EventAttributeDataFreeTier uint64
+	// CustomEventCost is charged per custom event emitted by a contract.
+	CustomEventCost sdk.Gas
+	// HumanizeCost is charged for each HumanAddress GoAPI callback a contract triggers.
+	HumanizeCost sdk.Gas
+	// CanonicalizeCost is charged for each CanonicalAddress GoAPI callback a contract triggers.
+	CanonicalizeCost sdk.Gas
+	// ValidateCost is charged for each ValidateAddress GoAPI callback a contract triggers.
+	ValidateCost sdk.Gas
+}
+
+// DefaultGasRegisterConfig returns the gas register configuration wasmd used to ship as
+// hard-coded constants.
+func DefaultGasRegisterConfig() WasmGasRegisterConfig {
+	return WasmGasRegisterConfig{
+		InstanceCost:               sdk.Gas(DefaultInstanceCost),
+		CompileCost:                sdk.Gas(DefaultCompileCost),
+		ContractMessageDataCost:    sdk.Gas(DefaultContractMessageDataCost),
+		GasMultiplier:              sdk.Gas(DefaultGasMultiplier),
+		EventPerAttributeCost:      sdk.Gas(DefaultPerAttributeCost),
+		EventAttributeDataCost:     sdk.Gas(DefaultEventAttributeDataCost),
+		EventAttributeDataFreeTier: DefaultEventAttributeDataFreeTier,
+		CustomEventCost:            sdk.Gas(DefaultPerCustomEventCost),
+		HumanizeCost:               sdk.Gas(DefaultHumanizeCost),
+		CanonicalizeCost:           sdk.Gas(DefaultCanonicalizeCost),
+		ValidateCost:               sdk.Gas(DefaultValidateCost),
+	}
+}
+
+// WasmGasRegister is the default GasRegister implementation, backed by a WasmGasRegisterConfig.
+type WasmGasRegister struct {
+	c WasmGasRegisterConfig
+}
+
+// NewDefaultWasmGasRegister creates a WasmGasRegister using DefaultGasRegisterConfig.
+func NewDefaultWasmGasRegister() WasmGasRegister {
+	return NewWasmGasRegister(DefaultGasRegisterConfig())
+}
+
+// NewWasmGasRegister constructor
+func NewWasmGasRegister(c WasmGasRegisterConfig) WasmGasRegister {
+	return WasmGasRegister{c: c}
+}
+
+// NewContractInstanceCosts implements GasRegister.
+func (g WasmGasRegister) NewContractInstanceCosts(pinned bool, msgLen int) sdk.Gas {
+	dataCosts := sdk.Gas(msgLen) * g.c.ContractMessageDataCost
+	if pinned {
+		return dataCosts
+	}
+	return g.c.InstanceCost + dataCosts
+}
+
+// CompileCosts implements GasRegister.
+func (g WasmGasRegister) CompileCosts(byteLen int) sdk.Gas {
+	if byteLen < 0 {
+		panic(sdk.ErrorOutOfGas{Descriptor: "negative contract byte length"})
+	}
+	return g.c.CompileCost * sdk.Gas(byteLen)
+}
+
+// ReplyCosts implements GasRegister.
+func (g WasmGasRegister) ReplyCosts(pinned bool, reply wasmvmtypes.Reply) sdk.Gas {
+	var (
+		eventGas sdk.Gas
+		msgLen   int
+	)
+	if reply.Result.Err == "" {
+		eventGas = g.EventCosts(nil, reply.Result.Ok.Events)
+		msgLen = len(reply.Result.Ok.Data)
+	} else {
+		msgLen = len(reply.Result.Err)
+	}
+	return eventGas + g.NewContractInstanceCosts(pinned, msgLen)
+}
+
+// EventCosts implements GasRegister.
+func (g WasmGasRegister) EventCosts(attrs []wasmvmtypes.EventAttribute, events wasmvmtypes.Events) sdk.Gas {
+	gas, freeTier := g.eventAttributeCosts(attrs, g.c.EventAttributeDataFreeTier)
+	for _, e := range events {
+		gas += g.c.CustomEventCost
+		attrGas, remaining := g.eventAttributeCosts(e.Attributes, freeTier)
+		gas += attrGas + sdk.Gas(len(e.Type))*g.c.EventAttributeDataCost
+		freeTier = remaining
+	}
+	return gas
+}
+
+func (g WasmGasRegister) eventAttributeCosts(attrs []wasmvmtypes.EventAttribute, freeTier uint64) (sdk.Gas, uint64) {
+	if len(attrs) == 0 {
+		return 0, freeTier
+	}
+	gas := sdk.Gas(len(attrs)) * g.c.EventPerAttributeCost
+	var totalLen uint64
+	for _, attr := range attrs {
+		totalLen += uint64(len(attr.Key) + len(attr.Value))
+	}
+	chargeableLen := totalLen
+	switch {
+	case freeTier >= totalLen:
+		freeTier -= totalLen
+		chargeableLen = 0
+	case freeTier > 0:
+		chargeableLen -= freeTier
+		freeTier = 0
+	}
+	return gas + sdk.Gas(chargeableLen)*g.c.EventAttributeDataCost, freeTier
+}
+
+// HumanizeCost implements GasRegister.
+func (g WasmGasRegister) HumanizeCost() sdk.Gas {
+	return g.c.HumanizeCost
+}
+
+// CanonicalizeCost implements GasRegister.
+func (g WasmGasRegister) CanonicalizeCost() sdk.Gas {
+	return g.c.CanonicalizeCost
+}
+
+// ValidateCost implements GasRegister.
+func (g WasmGasRegister) ValidateCost() sdk.Gas {
+	return g.c.ValidateCost
+}
+
+// ToWasmVMGas implements GasRegister.
+func (g WasmGasRegister) ToWasmVMGas(source sdk.Gas) uint64 {
+	dest := source * g.c.GasMultiplier
+	if g.c.GasMultiplier != 0 && dest/g.c.GasMultiplier != source {
+		panic(sdk.ErrorOutOfGas{Descriptor: "ToWasmVMGas overflow"})
+	}
+	return uint64(dest)
+}
+
+// FromWasmVMGas implements GasRegister.
+func (g WasmGasRegister) FromWasmVMGas(source uint64) sdk.Gas {
+	if g.c.GasMultiplier == 0 {
+		return 0
+	}
+	return sdk.Gas(source) / g.c.GasMultiplier
+}
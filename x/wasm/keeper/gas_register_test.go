@@ -0,0 +1,50 @@
+package keeper
+
+import (
+	"testing"
+
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+	"github.com/stretchr/testify/assert"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestReplyCosts(t *testing.T) {
+	register := NewDefaultWasmGasRegister()
+
+	specs := map[string]struct {
+		pinned bool
+		reply  wasmvmtypes.Reply
+		exp    sdk.Gas
+	}{
+		"pinned, no events, no data": {
+			pinned: true,
+			reply:  wasmvmtypes.Reply{Result: wasmvmtypes.SubMsgResult{Ok: &wasmvmtypes.SubMsgResponse{}}},
+			exp:    0,
+		},
+		"unpinned, no events, no data": {
+			reply: wasmvmtypes.Reply{Result: wasmvmtypes.SubMsgResult{Ok: &wasmvmtypes.SubMsgResponse{}}},
+			exp:   sdk.Gas(DefaultInstanceCost),
+		},
+		"unpinned, one custom event, no attributes": {
+			reply: wasmvmtypes.Reply{Result: wasmvmtypes.SubMsgResult{Ok: &wasmvmtypes.SubMsgResponse{
+				Events: wasmvmtypes.Events{{Type: "wasm-custom"}},
+			}}},
+			// CustomEventCost for the event, plus the event type name billed once (not twice) as
+			// event-attribute data, plus the flat unpinned instance cost. Regression test for the
+			// double-charge bug where len(e.Type) leaked into NewContractInstanceCosts's msgLen on
+			// top of EventCosts already billing it.
+			exp: sdk.Gas(DefaultPerCustomEventCost) + sdk.Gas(len("wasm-custom"))*sdk.Gas(DefaultEventAttributeDataCost) + sdk.Gas(DefaultInstanceCost),
+		},
+		"err result, no events billed": {
+			reply: wasmvmtypes.Reply{Result: wasmvmtypes.SubMsgResult{Err: "boom"}},
+			exp:   sdk.Gas(DefaultInstanceCost) + sdk.Gas(len("boom"))*sdk.Gas(DefaultContractMessageDataCost),
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			got := register.ReplyCosts(spec.pinned, spec.reply)
+			assert.Equal(t, spec.exp, got)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package keeper
+
+import (
+	"context"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/CosmWasm/wasmd/x/wasm/types"
+)
+
+var _ types.QueryServer = GrpcQuerier{}
+
+// GrpcQuerier serves the x/wasm gRPC query endpoints. It carries its own gasLimit, independent of
+// whatever the surrounding transaction's gas meter is set to, since queries are not charged against
+// a signer.
+type GrpcQuerier struct {
+	cdc      codec.Codec
+	storeKey storetypes.StoreKey
+	keeper   Keeper
+	gasLimit sdk.Gas
+}
+
+// NewGrpcQuerier constructor
+func NewGrpcQuerier(cdc codec.Codec, storeKey storetypes.StoreKey, keeper Keeper, gasLimit uint64) GrpcQuerier {
+	return GrpcQuerier{cdc: cdc, storeKey: storeKey, keeper: keeper, gasLimit: sdk.Gas(gasLimit)}
+}
+
+// SmartContractState queries a contract via its smart query entry point, against a fresh gas
+// meter bounded by q.gasLimit, independent of the gas available on the request's context. An
+// out-of-gas panic from deep within the VM or the gas meter itself is recovered and turned into an
+// error response, so an abusive query payload cannot crash the serving goroutine.
+func (q GrpcQuerier) SmartContractState(c context.Context, req *types.QuerySmartContractStateRequest) (rsp *types.QuerySmartContractStateResponse, err error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	contractAddr, err := sdk.AccAddressFromBech32(req.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := sdk.UnwrapSDKContext(c).WithGasMeter(sdk.NewGasMeter(q.gasLimit))
+	defer recoverQueryOutOfGas(&err)
+
+	bz, err := q.keeper.QuerySmart(ctx, contractAddr, req.QueryData)
+	if err != nil {
+		return nil, err
+	}
+	return &types.QuerySmartContractStateResponse{Data: bz}, nil
+}
+
+// RawContractState queries a single key out of a contract's raw storage.
+func (q GrpcQuerier) RawContractState(c context.Context, req *types.QueryRawContractStateRequest) (rsp *types.QueryRawContractStateResponse, err error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	contractAddr, err := sdk.AccAddressFromBech32(req.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := sdk.UnwrapSDKContext(c).WithGasMeter(sdk.NewGasMeter(q.gasLimit))
+	defer recoverQueryOutOfGas(&err)
+
+	return &types.QueryRawContractStateResponse{Data: q.keeper.QueryRaw(ctx, contractAddr, req.QueryData)}, nil
+}
+
+// AllContractState iterates the full raw key space of a contract.
+func (q GrpcQuerier) AllContractState(c context.Context, req *types.QueryAllContractStateRequest) (rsp *types.QueryAllContractStateResponse, err error) {
+	if req == nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	contractAddr, err := sdk.AccAddressFromBech32(req.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := sdk.UnwrapSDKContext(c).WithGasMeter(sdk.NewGasMeter(q.gasLimit))
+	defer recoverQueryOutOfGas(&err)
+
+	return q.keeper.QueryAllState(ctx, contractAddr)
+}
+
+// recoverQueryOutOfGas converts an out-of-gas panic raised by the gas meter (either sdk's own
+// ErrorOutOfGas, or storetypes.ErrorGasOverflow when accounting overflows) into the same
+// sdkerrors.ErrOutOfGas an external caller would see from any other overspent query, instead of
+// letting it unwind past the gRPC handler and crash the serving goroutine. Any other panic is
+// re-raised unchanged.
+func recoverQueryOutOfGas(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	switch rType := r.(type) {
+	case sdk.ErrorOutOfGas:
+		*err = sdkerrors.Wrapf(sdkerrors.ErrOutOfGas, "out of gas in location: %v", rType.Descriptor)
+	case storetypes.ErrorGasOverflow:
+		*err = sdkerrors.Wrapf(sdkerrors.ErrOutOfGas, "gas overflow in location: %v", rType.Descriptor)
+	default:
+		panic(r)
+	}
+}
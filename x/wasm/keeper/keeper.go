@@ -0,0 +1,188 @@
+package keeper
+
+import (
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/store/prefix"
+	storetypes "github.com/cosmos/cosmos-sdk/store/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	wasmvm "github.com/CosmWasm/wasmvm"
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+
+	"github.com/CosmWasm/wasmd/x/wasm/types"
+)
+
+// contractStorePrefixKey is the prefix byte under which every contract's raw key/value storage is
+// namespaced within the module's store.
+var contractStorePrefixKey = byte(0x03)
+
+func contractStorePrefix(contractAddr sdk.AccAddress) []byte {
+	return append([]byte{contractStorePrefixKey}, contractAddr.Bytes()...)
+}
+
+// WasmEngine defines the subset of the wasmvm.VM surface the keeper drives. It is kept as an
+// interface, rather than a concrete *wasmvm.VM, so that tests can substitute a mock engine.
+type WasmEngine interface {
+	Instantiate(env wasmvmtypes.Env, info wasmvmtypes.MessageInfo, contractAddr sdk.AccAddress, initMsg []byte, goapi wasmvm.GoAPI, querier wasmvm.Querier, gasMeter wasmvm.GasMeter, gasLimit uint64) (*wasmvmtypes.Response, uint64, error)
+	Execute(env wasmvmtypes.Env, info wasmvmtypes.MessageInfo, contractAddr sdk.AccAddress, executeMsg []byte, goapi wasmvm.GoAPI, querier wasmvm.Querier, gasMeter wasmvm.GasMeter, gasLimit uint64) (*wasmvmtypes.Response, uint64, error)
+	Migrate(env wasmvmtypes.Env, contractAddr sdk.AccAddress, migrateMsg []byte, goapi wasmvm.GoAPI, querier wasmvm.Querier, gasMeter wasmvm.GasMeter, gasLimit uint64) (*wasmvmtypes.Response, uint64, error)
+	Reply(env wasmvmtypes.Env, contractAddr sdk.AccAddress, reply wasmvmtypes.Reply, goapi wasmvm.GoAPI, querier wasmvm.Querier, gasMeter wasmvm.GasMeter, gasLimit uint64) (*wasmvmtypes.Response, uint64, error)
+	Query(env wasmvmtypes.Env, contractAddr sdk.AccAddress, queryMsg []byte, goapi wasmvm.GoAPI, querier wasmvm.Querier, gasMeter wasmvm.GasMeter, gasLimit uint64) ([]byte, uint64, error)
+}
+
+// Keeper has a reference to the wasm engine it drives, plus everything needed to turn that raw
+// VM access into gas-accounted, store-backed contract calls.
+type Keeper struct {
+	storeKey      storetypes.StoreKey
+	cdc           codec.Codec
+	wasmVM        WasmEngine
+	queryGasLimit sdk.Gas
+	gasRegister   GasRegister
+	queryPlugins  QueryPlugins
+}
+
+// Option is an extension point to instantiate keeper with non-default values.
+type Option interface {
+	apply(*Keeper)
+}
+
+type optsFn func(*Keeper)
+
+func (f optsFn) apply(keeper *Keeper) {
+	f(keeper)
+}
+
+// WithQueryHandlerDecorator is an optional constructor parameter to wrap the keeper's wasm
+// sub-query handler, e.g. to add metrics or recursion guards without modifying the keeper itself.
+func WithQueryHandlerDecorator(d QueryHandlerDecorator) Option {
+	return optsFn(func(k *Keeper) {
+		k.queryPlugins.Query = d(k.queryPlugins.Query)
+	})
+}
+
+// WithGasRegister is an optional constructor parameter to replace the default GasRegister, e.g.
+// for a chain that wants different pricing for instantiation, compilation or event emission.
+func WithGasRegister(g GasRegister) Option {
+	return optsFn(func(k *Keeper) {
+		k.gasRegister = g
+	})
+}
+
+// NewKeeper creates a new contract Keeper instance.
+func NewKeeper(
+	cdc codec.Codec,
+	storeKey storetypes.StoreKey,
+	wasmVM WasmEngine,
+	queryGasLimit uint64,
+	opts ...Option,
+) Keeper {
+	keeper := Keeper{
+		storeKey:      storeKey,
+		cdc:           cdc,
+		wasmVM:        wasmVM,
+		queryGasLimit: sdk.Gas(queryGasLimit),
+		gasRegister:   NewDefaultWasmGasRegister(),
+	}
+	keeper.queryPlugins = NewQueryPlugins(&keeper)
+	for _, o := range opts {
+		o.apply(&keeper)
+	}
+	return keeper
+}
+
+// capContractGasMeter bounds a single top-level contract call to min(ctx's remaining gas,
+// Params.MaxContractGas), returning a context carrying a fresh gas meter when the cap actually
+// bites. A MaxContractGas of 0 disables the cap, leaving ctx untouched. Callers must propagate
+// gas consumed on the returned context back onto the original ctx's meter once the call returns,
+// since the two are no longer the same object when the cap applies.
+func (k Keeper) capContractGasMeter(ctx sdk.Context) sdk.Context {
+	maxGas := k.GetParams(ctx).MaxContractGas
+	remaining := ctx.GasMeter().GasRemaining()
+	if maxGas == 0 || maxGas >= remaining {
+		return ctx
+	}
+	return ctx.WithGasMeter(sdk.NewGasMeter(maxGas))
+}
+
+// QuerySmart queries the smart contract itself. The result is not prefixed with a success/failure
+// indicator; callers that need to distinguish contract errors from transport errors should inspect
+// the returned error.
+func (k Keeper) QuerySmart(ctx sdk.Context, contractAddr sdk.AccAddress, req []byte) ([]byte, error) {
+	res, _, err := k.querySmart(ctx, contractAddr, req, nil)
+	return res, err
+}
+
+// QuerySmartWithProfile behaves exactly like QuerySmart, but additionally returns a structured
+// types.GasProfile breakdown of the call. Building the breakdown has a real cost (tracking a
+// per-sub-query ledger through arbitrarily deep recursion), so it only happens when
+// types.EnableGasVerification is on; outside of that, this degrades to QuerySmart plus a zero
+// GasProfile. It exists so gas regression tests can assert on specific cost categories and
+// sub-query counts instead of a single hand-tuned total that drifts with every wasmvm or gas
+// register change.
+func (k Keeper) QuerySmartWithProfile(ctx sdk.Context, contractAddr sdk.AccAddress, req []byte) ([]byte, types.GasProfile, error) {
+	if !types.EnableGasVerification {
+		res, err := k.QuerySmart(ctx, contractAddr, req)
+		return res, types.GasProfile{}, err
+	}
+	return k.querySmart(ctx, contractAddr, req, &types.GasProfile{})
+}
+
+// querySmart is the shared implementation behind QuerySmart and QuerySmartWithProfile. profile is
+// nil unless the caller wants the structured breakdown.
+func (k Keeper) querySmart(ctx sdk.Context, contractAddr sdk.AccAddress, req []byte, profile *types.GasProfile) ([]byte, types.GasProfile, error) {
+	parentMeter := ctx.GasMeter()
+	ctx = k.capContractGasMeter(ctx)
+	if profile != nil {
+		ctx = ctx.WithValue(contextKeyGasProfile{}, profile)
+	}
+
+	instanceCost := k.gasRegister.NewContractInstanceCosts(false, len(req))
+	ctx.GasMeter().ConsumeGas(instanceCost, "loading CosmWasm module: query")
+
+	env := types.NewEnv(ctx, contractAddr)
+	queryHandler := NewQueryHandler(ctx, contractAddr, k.queryPlugins, k)
+
+	gasLeft := k.gasRegister.ToWasmVMGas(ctx.GasMeter().GasRemaining())
+	res, gasUsed, err := k.wasmVM.Query(env, contractAddr, req, k.cosmwasmAPI(ctx), queryHandler, ctx.GasMeter(), gasLeft)
+	vmCost := k.gasRegister.FromWasmVMGas(gasUsed)
+	ctx.GasMeter().ConsumeGas(vmCost, "query smart contract")
+
+	if ctx.GasMeter() != parentMeter {
+		parentMeter.ConsumeGas(ctx.GasMeter().GasConsumed(), "contract call bounded by MaxContractGas")
+	}
+	if profile != nil {
+		profile.InstanceCost = uint64(instanceCost)
+		profile.VMCost = uint64(vmCost)
+	}
+	if err != nil {
+		if profile != nil {
+			return nil, *profile, sdkerrors.Wrap(types.ErrQueryFailed, err.Error())
+		}
+		return nil, types.GasProfile{}, sdkerrors.Wrap(types.ErrQueryFailed, err.Error())
+	}
+	if profile != nil {
+		return res, *profile, nil
+	}
+	return res, types.GasProfile{}, nil
+}
+
+// QueryRaw returns the value stored under key in the contract's own storage, or nil if absent.
+// Unlike QuerySmart, this bypasses the contract's query entry point entirely.
+func (k Keeper) QueryRaw(ctx sdk.Context, contractAddr sdk.AccAddress, key []byte) []byte {
+	store := prefix.NewStore(ctx.KVStore(k.storeKey), contractStorePrefix(contractAddr))
+	return store.Get(key)
+}
+
+// QueryAllState returns every key/value pair in the contract's own storage.
+func (k Keeper) QueryAllState(ctx sdk.Context, contractAddr sdk.AccAddress) (*types.QueryAllContractStateResponse, error) {
+	store := prefix.NewStore(ctx.KVStore(k.storeKey), contractStorePrefix(contractAddr))
+	iter := store.Iterator(nil, nil)
+	defer iter.Close()
+
+	models := make([]types.Model, 0)
+	for ; iter.Valid(); iter.Next() {
+		models = append(models, types.Model{Key: iter.Key(), Value: iter.Value()})
+	}
+	return &types.QueryAllContractStateResponse{Models: models}, nil
+}
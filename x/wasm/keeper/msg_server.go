@@ -0,0 +1,35 @@
+package keeper
+
+import (
+	"context"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+
+	"github.com/CosmWasm/wasmd/x/wasm/types"
+)
+
+// msgServer wraps Keeper to implement the x/wasm Msg gRPC service.
+type msgServer struct {
+	Keeper
+	authority string
+}
+
+// NewMsgServerImpl returns an implementation of types.MsgServer for the provided Keeper. Calls
+// that change module-wide parameters are gated on authority, the gov module account in production.
+func NewMsgServerImpl(keeper Keeper, authority string) types.MsgServer {
+	return &msgServer{Keeper: keeper, authority: authority}
+}
+
+// UpdateParams implements types.MsgServer. Only the configured authority may update the module
+// parameters, e.g. MaxContractGas, MaxSubQueryGas and MaxQueryDepth.
+func (m msgServer) UpdateParams(goCtx context.Context, msg *types.MsgUpdateParams) (*types.MsgUpdateParamsResponse, error) {
+	if m.authority != msg.Authority {
+		return nil, sdkerrors.Wrapf(types.ErrUnauthorized, "invalid authority; expected %s, got %s", m.authority, msg.Authority)
+	}
+	ctx := sdk.UnwrapSDKContext(goCtx)
+	if err := m.Keeper.SetParams(ctx, msg.Params); err != nil {
+		return nil, err
+	}
+	return &types.MsgUpdateParamsResponse{}, nil
+}
@@ -0,0 +1,39 @@
+package keeper
+
+import (
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/CosmWasm/wasmd/x/wasm/types"
+)
+
+// paramsStoreKey is the single key under which the module's Params blob is stored.
+var paramsStoreKey = []byte{0x05}
+
+// GetParams returns the current x/wasm module parameters, falling back to types.DefaultParams if
+// none have been set yet, e.g. on a chain that upgraded from a version predating this param set.
+func (k Keeper) GetParams(ctx sdk.Context) types.Params {
+	bz := ctx.KVStore(k.storeKey).Get(paramsStoreKey)
+	if bz == nil {
+		return types.DefaultParams()
+	}
+	var params types.Params
+	if err := json.Unmarshal(bz, &params); err != nil {
+		panic(err)
+	}
+	return params
+}
+
+// SetParams validates and persists the given x/wasm module parameters.
+func (k Keeper) SetParams(ctx sdk.Context, params types.Params) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	bz, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	ctx.KVStore(k.storeKey).Set(paramsStoreKey, bz)
+	return nil
+}
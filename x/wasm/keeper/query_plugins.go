@@ -0,0 +1,135 @@
+package keeper
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+
+	"github.com/CosmWasm/wasmd/x/wasm/types"
+)
+
+// contextKeyQueryStackSize is the context value key under which the current contract-query
+// recursion depth is tracked, so QueryHandler.Query can enforce Params.MaxQueryDepth without
+// threading an extra argument through the wasmvm.Querier interface.
+type contextKeyQueryStackSize struct{}
+
+func queryStackSize(ctx sdk.Context) uint32 {
+	if v, ok := ctx.Value(contextKeyQueryStackSize{}).(uint32); ok {
+		return v
+	}
+	return 0
+}
+
+// contextKeyGasProfile is the context value key under which an in-flight *types.GasProfile is
+// carried, so that sub-queries dispatched arbitrarily deep can append their own QueryGasRecord
+// without QuerySmartWithProfile threading a profile argument through every call in between. It is
+// only ever set when types.EnableGasVerification is on.
+type contextKeyGasProfile struct{}
+
+func gasProfileFromContext(ctx sdk.Context) *types.GasProfile {
+	v, _ := ctx.Value(contextKeyGasProfile{}).(*types.GasProfile)
+	return v
+}
+
+// WasmVMQueryHandler is the bridge between a wasmvm smart/raw query issued by a contract and the
+// keeper's own query routing. It is deliberately an interface (rather than a plain func) so it can
+// be wrapped by decorators, e.g. ones passed to NewKeeper via WithQueryHandlerDecorator.
+type WasmVMQueryHandler interface {
+	// HandleQuery executes the given wasmvm query on behalf of caller and returns the raw,
+	// contract-facing response bytes.
+	HandleQuery(ctx sdk.Context, caller sdk.AccAddress, request wasmvmtypes.QueryRequest) ([]byte, error)
+}
+
+// WasmVMQueryHandlerFn is a WasmVMQueryHandler built from a plain function, following the same
+// pattern as http.HandlerFunc.
+type WasmVMQueryHandlerFn func(ctx sdk.Context, caller sdk.AccAddress, request wasmvmtypes.QueryRequest) ([]byte, error)
+
+// HandleQuery implements WasmVMQueryHandler.
+func (f WasmVMQueryHandlerFn) HandleQuery(ctx sdk.Context, caller sdk.AccAddress, request wasmvmtypes.QueryRequest) ([]byte, error) {
+	return f(ctx, caller, request)
+}
+
+// QueryHandlerDecorator wraps a WasmVMQueryHandler to add cross-cutting behaviour (metrics,
+// recursion limits, ...) around the real query dispatch.
+type QueryHandlerDecorator func(old WasmVMQueryHandler) WasmVMQueryHandler
+
+// QueryPlugins holds the keeper's wasmvm query dispatcher, built once in NewKeeper and then reused
+// for every contract-originated query.
+type QueryPlugins struct {
+	Query WasmVMQueryHandler
+}
+
+// QueryHandler adapts a QueryPlugins into the wasmvm.Querier interface expected by the VM, so a
+// contract-originated sub-query is routed back through the same keeper dispatch as a top-level one.
+// It is also where the recursive-query gas amplification attack is blocked: every sub-query gets
+// its own gas meter capped at Params.MaxSubQueryGas and a recursion depth capped at
+// Params.MaxQueryDepth, so a contract cannot outrun either bound by nesting queries into itself.
+type QueryHandler struct {
+	Ctx     sdk.Context
+	Caller  sdk.AccAddress
+	Plugins QueryPlugins
+	Keeper  Keeper
+}
+
+// NewQueryHandler constructor
+func NewQueryHandler(ctx sdk.Context, caller sdk.AccAddress, plugins QueryPlugins, keeper Keeper) QueryHandler {
+	return QueryHandler{Ctx: ctx, Caller: caller, Plugins: plugins, Keeper: keeper}
+}
+
+// GasConsumed implements wasmvm.Querier.
+func (q QueryHandler) GasConsumed() uint64 {
+	return q.Ctx.GasMeter().GasConsumed()
+}
+
+// Query implements wasmvm.Querier, dispatching the sub-query back through QueryPlugins.Query under
+// a bounded gas meter and recursion depth.
+func (q QueryHandler) Query(request wasmvmtypes.QueryRequest, _ uint64) ([]byte, error) {
+	params := q.Keeper.GetParams(q.Ctx)
+
+	depth := queryStackSize(q.Ctx)
+	if depth >= params.MaxQueryDepth {
+		return nil, types.ErrExceedMaxQueryDepth
+	}
+
+	subGasLimit := q.Ctx.GasMeter().GasRemaining()
+	if params.MaxSubQueryGas < subGasLimit {
+		subGasLimit = params.MaxSubQueryGas
+	}
+	subCtx := q.Ctx.WithGasMeter(sdk.NewGasMeter(subGasLimit)).WithValue(contextKeyQueryStackSize{}, depth+1)
+	if profile := gasProfileFromContext(q.Ctx); profile != nil {
+		subCtx = subCtx.WithValue(contextKeyGasProfile{}, profile)
+	}
+
+	res, err := q.Plugins.Query.HandleQuery(subCtx, q.Caller, request)
+	gasConsumed := subCtx.GasMeter().GasConsumed()
+	q.Ctx.GasMeter().ConsumeGas(gasConsumed, "wasm sub-query")
+	if profile := gasProfileFromContext(q.Ctx); profile != nil {
+		profile.PerQueryBreakdown = append(profile.PerQueryBreakdown, types.QueryGasRecord{Depth: depth + 1, Gas: gasConsumed})
+	}
+	return res, err
+}
+
+// NewQueryPlugins wires the keeper's own wasm sub-query dispatch into a WasmVMQueryHandler, so that
+// a contract issuing wasmvmtypes.WasmQuery{Smart: ...} is routed back through keeper.QuerySmart.
+func NewQueryPlugins(keeper *Keeper) QueryPlugins {
+	return QueryPlugins{
+		Query: WasmVMQueryHandlerFn(func(ctx sdk.Context, caller sdk.AccAddress, request wasmvmtypes.QueryRequest) ([]byte, error) {
+			switch {
+			case request.Wasm != nil && request.Wasm.Smart != nil:
+				addr, err := sdk.AccAddressFromBech32(request.Wasm.Smart.ContractAddr)
+				if err != nil {
+					return nil, err
+				}
+				return keeper.QuerySmart(ctx, addr, request.Wasm.Smart.Msg)
+			case request.Wasm != nil && request.Wasm.Raw != nil:
+				addr, err := sdk.AccAddressFromBech32(request.Wasm.Raw.ContractAddr)
+				if err != nil {
+					return nil, err
+				}
+				return keeper.QueryRaw(ctx, addr, request.Wasm.Raw.Key), nil
+			default:
+				return nil, wasmvmtypes.UnsupportedRequest{Kind: "unknown wasm query variant"}
+			}
+		}),
+	}
+}
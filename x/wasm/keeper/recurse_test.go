@@ -179,6 +179,16 @@ func TestGasOnExternalQuery(t *testing.T) {
 			},
 			expOutOfGas: true,
 		},
+		"deeply recursive payload, external gas limit": {
+			// enough gas to recurse several levels deep and burn a lot of CPU, but not enough to
+			// finish; this used to panic out of SmartContractState instead of returning an error
+			gasLimit: 400_000,
+			msg: Recurse{
+				Depth: 50,
+				Work:  2000,
+			},
+			expOutOfGas: true,
+		},
 	}
 
 	contractAddr, ctx, keeper := initRecurseContract(t)
@@ -306,3 +316,110 @@ func TestLimitRecursiveQueryGas(t *testing.T) {
 		})
 	}
 }
+
+func TestMaxSubQueryGasCapsRecursiveAmplification(t *testing.T) {
+	// Same attack as TestLimitRecursiveQueryGas, but asserting the explicit defenses added on top
+	// of wasmvm's own recursion depth: a contract-originated sub-query never sees more than
+	// Params.MaxSubQueryGas, and nesting past Params.MaxQueryDepth is rejected outright, so the
+	// total gas burned by a recursive attack is bounded regardless of the original gasLimit.
+	cases := map[string]struct {
+		maxSubQueryGas uint64
+		maxQueryDepth  uint32
+		gasLimit       uint64
+		msg            Recurse
+		expectOutOfGas bool
+		expectError    string
+	}{
+		"sub query gas cap bounds deep recursion": {
+			maxSubQueryGas: 50_000,
+			maxQueryDepth:  10,
+			gasLimit:       50_000_000,
+			msg: Recurse{
+				Depth: 50,
+				Work:  2000,
+			},
+			expectOutOfGas: true,
+		},
+		"query depth cap rejects recursion before gas runs out": {
+			maxSubQueryGas: 4_000_000,
+			maxQueryDepth:  2,
+			gasLimit:       4_000_000,
+			msg: Recurse{
+				Depth: 50,
+				Work:  1,
+			},
+			expectError: types.ErrExceedMaxQueryDepth.Error(),
+		},
+	}
+
+	contractAddr, ctx, keeper := initRecurseContract(t)
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, keeper.SetParams(ctx, types.Params{
+				MaxSubQueryGas: tc.maxSubQueryGas,
+				MaxQueryDepth:  tc.maxQueryDepth,
+			}))
+
+			ctx = ctx.WithGasMeter(sdk.NewGasMeter(tc.gasLimit))
+			msg := buildRecurseQuery(t, tc.msg)
+
+			if tc.expectOutOfGas {
+				require.Panics(t, func() {
+					_, _ = keeper.QuerySmart(ctx, contractAddr, msg)
+				})
+				return
+			}
+
+			_, err := keeper.QuerySmart(ctx, contractAddr, msg)
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			// the total gas consumed stays bounded by the depth cap even though gasLimit is huge
+			assert.LessOrEqual(t, ctx.GasMeter().GasConsumed(), uint64(tc.maxQueryDepth+1)*tc.maxSubQueryGas)
+		})
+	}
+}
+
+func TestMaxContractGasLimitsQuery(t *testing.T) {
+	// Params.MaxContractGas is a circuit breaker on a single contract call, independent of how
+	// much gas the enclosing tx has available: a query that would otherwise succeed must still
+	// fail deterministically with OutOfGas once it is capped below what the work requires.
+	contractAddr, ctx, keeper := initRecurseContract(t)
+
+	params := types.DefaultParams()
+	params.MaxContractGas = 10_000
+	require.NoError(t, keeper.SetParams(ctx, params))
+
+	ctx = ctx.WithGasMeter(sdk.NewGasMeter(10_000_000))
+	msg := buildRecurseQuery(t, Recurse{Work: 50})
+
+	require.Panics(t, func() {
+		_, _ = keeper.QuerySmart(ctx, contractAddr, msg)
+	})
+}
+
+func TestGasProfileOnQuery(t *testing.T) {
+	// Replaces the hand-tuned monolithic totals in TestGasCostOnQuery/TestLimitRecursiveQueryGas
+	// with assertions on the structured breakdown, which doesn't need rebaselining every time
+	// wasmvm or the gas register changes: the sub-query count and the fact that cost categories
+	// are non-zero are what matter here, not their exact values.
+	types.EnableGasVerification = true
+	defer func() { types.EnableGasVerification = false }()
+
+	contractAddr, ctx, keeper := initRecurseContract(t)
+	ctx = ctx.WithGasMeter(sdk.NewGasMeter(4_000_000))
+
+	msg := buildRecurseQuery(t, Recurse{Depth: 5, Work: 2000})
+	_, profile, err := keeper.QuerySmartWithProfile(ctx, contractAddr, msg)
+	require.NoError(t, err)
+
+	assert.NotZero(t, profile.InstanceCost)
+	assert.NotZero(t, profile.VMCost)
+	assert.Len(t, profile.PerQueryBreakdown, 5)
+	for _, rec := range profile.PerQueryBreakdown {
+		assert.NotZero(t, rec.Gas)
+	}
+}
@@ -0,0 +1,22 @@
+package types
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	wasmvmtypes "github.com/CosmWasm/wasmvm/types"
+)
+
+// NewEnv initializes the wasmvm.Env passed into every contract call, carrying the block and
+// contract context that a contract may read but never influence.
+func NewEnv(ctx sdk.Context, contractAddr sdk.AccAddress) wasmvmtypes.Env {
+	return wasmvmtypes.Env{
+		Block: wasmvmtypes.BlockInfo{
+			Height:  uint64(ctx.BlockHeight()),
+			Time:    uint64(ctx.BlockTime().UnixNano()),
+			ChainID: ctx.ChainID(),
+		},
+		Contract: wasmvmtypes.ContractInfo{
+			Address: contractAddr.String(),
+		},
+	}
+}
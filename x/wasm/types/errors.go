@@ -0,0 +1,16 @@
+package types
+
+import (
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// x/wasm module sentinel errors
+var (
+	ErrQueryFailed         = sdkerrors.Register(ModuleName, 2, "query wasm contract failed")
+	ErrInvalid             = sdkerrors.Register(ModuleName, 3, "invalid")
+	ErrExceedMaxQueryDepth = sdkerrors.Register(ModuleName, 4, "query recursion depth exceeds limit")
+	ErrUnauthorized        = sdkerrors.Register(ModuleName, 5, "unauthorized")
+)
+
+// ModuleName is the x/wasm module name used for error registration and routing.
+const ModuleName = "wasm"
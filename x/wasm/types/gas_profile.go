@@ -0,0 +1,33 @@
+package types
+
+// QueryGasRecord captures the gas charged for a single contract-originated sub-query, so gas
+// regression tests can assert on the exact recursion breakdown instead of a monolithic total.
+type QueryGasRecord struct {
+	// Depth is the sub-query's nesting level, 1 for a query issued directly by the top-level
+	// contract, 2 for a query issued by that sub-query's target, and so on.
+	Depth uint32 `json:"depth"`
+	// Gas is the SDK gas this particular sub-query consumed.
+	Gas uint64 `json:"gas"`
+}
+
+// GasProfile is a structured breakdown of the gas a single contract call consumed, captured when
+// EnableGasVerification is on. It lets tests (and operators wiring up Prometheus histograms)
+// attribute gas to a specific cause instead of asserting on a hand-tuned monolithic total that
+// drifts with every wasmvm or gas register change.
+type GasProfile struct {
+	// InstanceCost is the cost charged for loading the contract instance, including the per-byte
+	// cost of the message passed into the call (GasRegister.NewContractInstanceCosts).
+	InstanceCost uint64 `json:"instance_cost"`
+	// ReplyCost is the cost charged by GasRegister.ReplyCosts for handling a submessage reply, and
+	// is only populated when the profiled call is a reply.
+	ReplyCost uint64 `json:"reply_cost"`
+	// HostFnCost is the total SDK gas charged for GoAPI host callbacks (address humanize,
+	// canonicalize, validate) the contract triggered during the call.
+	HostFnCost uint64 `json:"host_fn_cost"`
+	// VMCost is the gas wasmvm itself reported as consumed, converted back to SDK gas.
+	VMCost uint64 `json:"vm_cost"`
+	// PerQueryBreakdown records one entry per contract-originated sub-query charged during the
+	// call, in the order they completed, so a nested sub-query appears before the query that
+	// issued it.
+	PerQueryBreakdown []QueryGasRecord `json:"per_query_breakdown"`
+}
@@ -0,0 +1,16 @@
+package types
+
+// GenesisState is the x/wasm genesis state.
+type GenesisState struct {
+	Params Params `json:"params"`
+}
+
+// DefaultGenesisState returns the default x/wasm genesis state.
+func DefaultGenesisState() GenesisState {
+	return GenesisState{Params: DefaultParams()}
+}
+
+// Validate performs basic genesis state validation, delegating to Params.Validate.
+func (gs GenesisState) Validate() error {
+	return gs.Params.Validate()
+}
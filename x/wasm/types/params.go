@@ -0,0 +1,51 @@
+package types
+
+// Default gas-related parameter values.
+const (
+	// DefaultMaxQueryDepth is the default maximum number of nested contract-to-contract queries
+	// allowed before a query is rejected outright.
+	DefaultMaxQueryDepth uint32 = 10
+	// DefaultMaxSubQueryGas is the default cap, in SDK gas, on a single contract-originated
+	// sub-query, regardless of how much gas remains on the caller's meter.
+	DefaultMaxSubQueryGas uint64 = 2_000_000
+	// DefaultMaxContractGas is the default cap on a single top-level contract call. 0 means no
+	// cap beyond whatever the enclosing transaction's own gas limit already enforces, so the
+	// default behavior is unchanged until a chain opts in via governance.
+	DefaultMaxContractGas uint64 = 0
+)
+
+// Params defines the set of x/wasm module parameters.
+type Params struct {
+	// MaxSubQueryGas caps the gas a contract-originated sub-query may consume, regardless of how
+	// much gas remains on the caller's meter. It blocks the amplification attack where a contract
+	// burns most of its gas in CPU and then issues a sub-query that still sees nearly the full
+	// original budget, repeating the pattern on every level of recursion.
+	MaxSubQueryGas uint64 `json:"max_sub_query_gas"`
+	// MaxQueryDepth is the maximum number of nested contract-to-contract queries allowed before a
+	// query is rejected outright, independent of how much gas remains.
+	MaxQueryDepth uint32 `json:"max_query_depth"`
+	// MaxContractGas caps a single top-level QuerySmart/execute/instantiate/migrate call,
+	// regardless of how much gas the enclosing transaction has available. 0 disables the cap, so
+	// a single call can still monopolize a block unless a chain explicitly opts in via governance.
+	MaxContractGas uint64 `json:"max_contract_gas"`
+}
+
+// DefaultParams returns the default x/wasm module parameters.
+func DefaultParams() Params {
+	return Params{
+		MaxSubQueryGas: DefaultMaxSubQueryGas,
+		MaxQueryDepth:  DefaultMaxQueryDepth,
+		MaxContractGas: DefaultMaxContractGas,
+	}
+}
+
+// Validate performs basic sanity checks on the param values.
+func (p Params) Validate() error {
+	if p.MaxSubQueryGas == 0 {
+		return ErrInvalid.Wrap("max sub query gas must be positive")
+	}
+	if p.MaxQueryDepth == 0 {
+		return ErrInvalid.Wrap("max query depth must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package types
+
+import "context"
+
+// QuerySmartContractStateRequest is the request type for the Query/SmartContractState RPC method.
+type QuerySmartContractStateRequest struct {
+	Address   string `json:"address"`
+	QueryData []byte `json:"query_data"`
+}
+
+// QuerySmartContractStateResponse is the response type for the Query/SmartContractState RPC method.
+type QuerySmartContractStateResponse struct {
+	Data []byte `json:"data"`
+}
+
+// QueryRawContractStateRequest is the request type for the Query/RawContractState RPC method.
+type QueryRawContractStateRequest struct {
+	Address   string `json:"address"`
+	QueryData []byte `json:"query_data"`
+}
+
+// QueryRawContractStateResponse is the response type for the Query/RawContractState RPC method.
+type QueryRawContractStateResponse struct {
+	Data []byte `json:"data"`
+}
+
+// QueryAllContractStateRequest is the request type for the Query/AllContractState RPC method.
+type QueryAllContractStateRequest struct {
+	Address string `json:"address"`
+}
+
+// Model is a single key/value pair from a contract's raw storage.
+type Model struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// QueryAllContractStateResponse is the response type for the Query/AllContractState RPC method.
+type QueryAllContractStateResponse struct {
+	Models []Model `json:"models"`
+}
+
+// QueryServer is the server API for the x/wasm Query gRPC service.
+type QueryServer interface {
+	SmartContractState(context.Context, *QuerySmartContractStateRequest) (*QuerySmartContractStateResponse, error)
+	RawContractState(context.Context, *QueryRawContractStateRequest) (*QueryRawContractStateResponse, error)
+	AllContractState(context.Context, *QueryAllContractStateRequest) (*QueryAllContractStateResponse, error)
+}
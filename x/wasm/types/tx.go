@@ -0,0 +1,20 @@
+package types
+
+import "context"
+
+// MsgUpdateParams is the governance-gated message used to update the x/wasm module parameters.
+type MsgUpdateParams struct {
+	// Authority is the address that is allowed to send this message, typically the gov module
+	// account.
+	Authority string `json:"authority"`
+	// Params is the complete parameter set to apply; it replaces the current set wholesale.
+	Params Params `json:"params"`
+}
+
+// MsgUpdateParamsResponse is the Msg/UpdateParams response type.
+type MsgUpdateParamsResponse struct{}
+
+// MsgServer is the server API for the x/wasm Msg gRPC service.
+type MsgServer interface {
+	UpdateParams(context.Context, *MsgUpdateParams) (*MsgUpdateParamsResponse, error)
+}